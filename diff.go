@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diff_op is the kind of change a line represents in a line-based diff.
+type diff_op int
+
+const (
+	diff_equal diff_op = iota
+	diff_delete
+	diff_insert
+)
+
+// diff_line is a single line of a line-based diff, tagged with the change it
+// represents.
+type diff_line struct {
+	op   diff_op
+	text string
+}
+
+// line_diff computes a line-based diff between a and b using the classic
+// longest-common-subsequence algorithm.
+//
+// Parameters:
+//   - a: The original lines.
+//   - b: The new lines.
+//
+// Returns:
+//   - []diff_line: The diff, as a sequence of equal, deleted, and inserted
+//     lines.
+func line_diff(a, b []string) []diff_line {
+	n, m := len(a), len(b)
+
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out []diff_line
+
+	i, j := 0, 0
+
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, diff_line{op: diff_equal, text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, diff_line{op: diff_delete, text: a[i]})
+			i++
+		default:
+			out = append(out, diff_line{op: diff_insert, text: b[j]})
+			j++
+		}
+	}
+
+	for ; i < n; i++ {
+		out = append(out, diff_line{op: diff_delete, text: a[i]})
+	}
+
+	for ; j < m; j++ {
+		out = append(out, diff_line{op: diff_insert, text: b[j]})
+	}
+
+	return out
+}
+
+// split_lines splits data into its lines, dropping the empty trailing entry
+// caused by a final newline.
+func split_lines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(string(data), "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// unified_diff renders a simplified unified diff between a and b, labelled
+// with a_name and b_name.
+//
+// Parameters:
+//   - a_name: The label for the original content, as shown on the "---" line.
+//   - b_name: The label for the new content, as shown on the "+++" line.
+//   - a: The original content.
+//   - b: The new content.
+//
+// Returns:
+//   - string: The diff, or an empty string if a and b are identical.
+//
+// Unlike a full 'diff -u', every line of context is included rather than
+// being collapsed into minimal hunks: generated files are usually short
+// enough that the extra context is not a problem, and this keeps the
+// implementation dependency-free.
+func unified_diff(a_name, b_name string, a, b []byte) string {
+	a_lines := split_lines(a)
+	b_lines := split_lines(b)
+
+	lines := line_diff(a_lines, b_lines)
+
+	changed := false
+
+	for _, l := range lines {
+		if l.op != diff_equal {
+			changed = true
+			break
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "--- %s\n", a_name)
+	fmt.Fprintf(&sb, "+++ %s\n", b_name)
+	fmt.Fprintf(&sb, "@@ -1,%d +1,%d @@\n", len(a_lines), len(b_lines))
+
+	for _, l := range lines {
+		switch l.op {
+		case diff_delete:
+			sb.WriteString("-" + l.text + "\n")
+		case diff_insert:
+			sb.WriteString("+" + l.text + "\n")
+		default:
+			sb.WriteString(" " + l.text + "\n")
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,35 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiffDetectsChange(t *testing.T) {
+	a := []byte("line1\nline2\nline3\n")
+	b := []byte("line1\nline3\nline4\n")
+
+	diff := unified_diff("a.go", "b.go", a, b)
+
+	if diff == "" {
+		t.Fatalf("expected a non-empty diff for differing input")
+	}
+
+	if !strings.Contains(diff, "-line2") {
+		t.Errorf("expected diff to report the removed line, got:\n%s", diff)
+	}
+
+	if !strings.Contains(diff, "+line4") {
+		t.Errorf("expected diff to report the added line, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiffEmptyForIdenticalInput(t *testing.T) {
+	data := []byte("line1\nline2\n")
+
+	diff := unified_diff("a.go", "b.go", data, data)
+
+	if diff != "" {
+		t.Errorf("expected no diff for identical input, got:\n%s", diff)
+	}
+}
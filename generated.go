@@ -1,14 +1,26 @@
 package generator
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/scanner"
 	"os"
 	"path/filepath"
 	"strings"
+
+	gers "github.com/PlayerR9/go-errors"
 )
 
 // go_ext is the extension of Go files.
 const go_ext string = ".go"
 
+// raw_ext is the extension used for the unformatted sibling file that is
+// written alongside the destination file when formatting fails and the
+// generator was configured to keep it around for debugging.
+const raw_ext string = ".go.raw"
+
 // Generated is the type containing the generated code and its location.
 type Generated struct {
 	// DestLoc is the destination location of the generated code.
@@ -16,6 +28,187 @@ type Generated struct {
 
 	// Data is the data to use for the generated code.
 	Data []byte
+
+	// KeepRawOnFormatError, when true, makes WriteFile write the unformatted
+	// data to a '.go.raw' sibling file when Format fails, so that the
+	// offending output can be inspected.
+	KeepRawOnFormatError bool
+
+	// Mode controls how WriteFile emits the generated code: to disk
+	// (WriteMode, the default), to stdout (PrintMode), or as a diff against
+	// what is already on disk (DiffMode, CheckMode).
+	Mode Mode
+
+	// header is extra text included in the header comment, alongside the
+	// canonical "Code generated ...; DO NOT EDIT." banner. Set via SetHeader.
+	header string
+
+	// build_tags holds the build constraint tags rendered as //go:build and
+	// // +build lines. Set via SetBuildTags.
+	build_tags []string
+
+	// generate_directive records the invoking `go generate` command, used to
+	// name the generator in the banner. Set via SetGenerateDirective.
+	generate_directive string
+
+	// banner_done guards against prepending the banner more than once if
+	// Format is called repeatedly.
+	banner_done bool
+}
+
+// SetHeader sets extra text to include in the header comment, alongside the
+// canonical "Code generated ...; DO NOT EDIT." banner.
+//
+// Parameters:
+//   - text: The text to include. Each line is rendered as its own comment
+//     line.
+//
+// Returns:
+//   - bool: True if the receiver is not nil, false otherwise.
+func (g *Generated) SetHeader(text string) bool {
+	if g == nil {
+		return false
+	}
+
+	g.header = text
+
+	return true
+}
+
+// SetBuildTags sets the build constraint tags to prepend as //go:build and
+// // +build lines.
+//
+// Parameters:
+//   - tags: The build tags to require, combined with AND.
+//
+// Returns:
+//   - bool: True if the receiver is not nil, false otherwise.
+func (g *Generated) SetBuildTags(tags ...string) bool {
+	if g == nil {
+		return false
+	}
+
+	g.build_tags = tags
+
+	return true
+}
+
+// SetGenerateDirective records the invoking `go generate` command, which is
+// used to name the generator in the canonical "Code generated ...; DO NOT
+// EDIT." banner, for provenance.
+//
+// Parameters:
+//   - cmd: The invoking command, e.g. "go-generator -type Stack".
+//
+// Returns:
+//   - bool: True if the receiver is not nil, false otherwise.
+func (g *Generated) SetGenerateDirective(cmd string) bool {
+	if g == nil {
+		return false
+	}
+
+	g.generate_directive = cmd
+
+	return true
+}
+
+// has_banner reports whether g was configured, via SetHeader, SetBuildTags,
+// or SetGenerateDirective, to carry a header banner.
+func (g *Generated) has_banner() bool {
+	return g.header != "" || len(g.build_tags) > 0 || g.generate_directive != ""
+}
+
+// banner renders the header comment and build constraint lines to prepend to
+// g.Data, with the blank-line separation gofmt requires between build
+// constraints, the package clause, and everything else.
+func (g *Generated) banner() string {
+	source := g.generate_directive
+	if source == "" {
+		source = "go-generator"
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "// Code generated by %s; DO NOT EDIT.\n", source)
+
+	if g.header != "" {
+		sb.WriteString("//\n")
+
+		for _, line := range strings.Split(g.header, "\n") {
+			sb.WriteString("// " + line + "\n")
+		}
+	}
+
+	sb.WriteString("\n")
+
+	if len(g.build_tags) > 0 {
+		fmt.Fprintf(&sb, "//go:build %s\n", strings.Join(g.build_tags, " && "))
+		fmt.Fprintf(&sb, "// +build %s\n\n", strings.Join(g.build_tags, ","))
+	}
+
+	return sb.String()
+}
+
+// ErrDiffers is returned by Generated.WriteFile in DiffMode and CheckMode
+// when the generated code differs from what is on disk.
+var ErrDiffers = errors.New("generated code differs from what is on disk")
+
+// Format runs go/format.Source over g.Data and, on success, replaces g.Data
+// with the formatted result.
+//
+// Returns:
+//   - error: An error if the data is not syntactically valid Go code.
+//
+// On failure, the returned error includes the offending line number together
+// with its content, similarly to how gofmt reports syntax errors.
+func (g *Generated) Format() error {
+	if g == nil {
+		return gers.NewErrInvalidParameter("Generated.Format()", "receiver must not be nil")
+	}
+
+	if g.has_banner() && !g.banner_done {
+		g.Data = append([]byte(g.banner()), g.Data...)
+		g.banner_done = true
+	}
+
+	formatted, err := format.Source(g.Data)
+	if err != nil {
+		return annotate_format_error(g.Data, err)
+	}
+
+	g.Data = formatted
+
+	return nil
+}
+
+// annotate_format_error enriches a go/format.Source error with the content of
+// the offending line, so that formatting failures read like gofmt's own
+// syntax error output.
+//
+// Parameters:
+//   - src: The source that was passed to go/format.Source.
+//   - err: The error returned by go/format.Source.
+//
+// Returns:
+//   - error: The annotated error, or err unchanged if it does not carry
+//     position information.
+func annotate_format_error(src []byte, err error) error {
+	var list scanner.ErrorList
+
+	if !errors.As(err, &list) || len(list) == 0 {
+		return err
+	}
+
+	first := list[0]
+
+	var context string
+
+	lines := bytes.Split(src, []byte("\n"))
+	if first.Pos.Line >= 1 && first.Pos.Line <= len(lines) {
+		context = string(lines[first.Pos.Line-1])
+	}
+
+	return fmt.Errorf("%w\n\t%d: %s", err, first.Pos.Line, context)
 }
 
 // ModifySuffixPath modifies the path of the generated code.
@@ -104,29 +297,118 @@ func (g *Generated) ReplaceFileName(file_name string) bool {
 	return true
 }
 
-// WriteFile writes the generated code to the destination file.
+// format_with_raw_fallback formats g and, on failure, writes the unformatted
+// data to a '.go.raw' sibling of g.DestLoc if g.KeepRawOnFormatError is set.
+//
+// Returns:
+//   - error: The formatting error, if any, regardless of whether the raw
+//     sibling was written.
+func (g *Generated) format_with_raw_fallback() error {
+	err := g.Format()
+	if err == nil {
+		return nil
+	}
+
+	if g.KeepRawOnFormatError {
+		dir := filepath.Dir(g.DestLoc)
+
+		if mkdir_err := os.MkdirAll(dir, 0755); mkdir_err != nil {
+			return fmt.Errorf("%w (and failed to prepare raw sibling directory: %s)", err, mkdir_err.Error())
+		}
+
+		raw_loc := strings.TrimSuffix(g.DestLoc, go_ext) + raw_ext
+
+		if raw_err := os.WriteFile(raw_loc, g.Data, 0644); raw_err != nil {
+			return fmt.Errorf("%w (and failed to write raw sibling: %s)", err, raw_err.Error())
+		}
+	}
+
+	return err
+}
+
+// atomic_write writes data to dest by staging it in a temporary file in the
+// same directory and renaming it into place, so that dest is never left
+// truncated or partially written if the write fails partway through.
 //
 // Parameters:
-//   - suffix: The suffix to add to the file name. If empty, no suffix is added.
-//   - sub_directories: The sub directories to create the file in.
+//   - dest: The destination file to write.
+//   - data: The data to write.
 //
 // Returns:
 //   - error: An error if occurred.
-//
-// The suffix is useful for when generating multiple files as it adds a suffix without
-// changing the extension.
-func (g Generated) WriteFile() error {
-	dir := filepath.Dir(g.DestLoc)
+func atomic_write(dest string, data []byte) error {
+	dir := filepath.Dir(dest)
 
-	err := os.MkdirAll(dir, 0755)
-	if err != nil {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
-	err = os.WriteFile(g.DestLoc, g.Data, 0644)
+	tmp, err := os.CreateTemp(dir, ".go-generator-tmp-*"+go_ext)
 	if err != nil {
 		return err
 	}
+	tmp_loc := tmp.Name()
+
+	_, write_err := tmp.Write(data)
+	close_err := tmp.Close()
+
+	if write_err != nil {
+		os.Remove(tmp_loc)
+		return write_err
+	} else if close_err != nil {
+		os.Remove(tmp_loc)
+		return close_err
+	}
+
+	if err := os.Rename(tmp_loc, dest); err != nil {
+		os.Remove(tmp_loc)
+		return err
+	}
 
 	return nil
 }
+
+// WriteFile formats the generated code and emits it according to g.Mode.
+//
+// Returns:
+//   - error: An error if occurred.
+//
+// If formatting fails and g.KeepRawOnFormatError is true, the unformatted data
+// is additionally written to a '.go.raw' sibling of the destination file so
+// that the offending output can be inspected; the formatting error is still
+// returned in that case, regardless of g.Mode.
+//
+// In DiffMode and CheckMode, ErrDiffers is returned if the generated code
+// differs from what is currently on disk; DiffMode additionally prints the
+// diff to stdout.
+func (g Generated) WriteFile() error {
+	if format_err := g.format_with_raw_fallback(); format_err != nil {
+		return format_err
+	}
+
+	switch g.Mode {
+	case PrintMode:
+		fmt.Printf("// %s\n", g.DestLoc)
+		fmt.Print(string(g.Data))
+
+		return nil
+	case DiffMode, CheckMode:
+		existing, err := os.ReadFile(g.DestLoc)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		diff := unified_diff(g.DestLoc, g.DestLoc, existing, g.Data)
+		if diff == "" {
+			return nil
+		}
+
+		if g.Mode == DiffMode {
+			fmt.Print(diff)
+		}
+
+		return ErrDiffers
+	default:
+		return atomic_write(g.DestLoc, g.Data)
+	}
+}
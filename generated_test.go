@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedFormatRendersBanner(t *testing.T) {
+	g := &Generated{
+		Data: []byte("package p\n\nvar X = 1\n"),
+	}
+
+	g.SetGenerateDirective("go-generator -type Stack")
+	g.SetHeader("source: stack.go")
+	g.SetBuildTags("linux", "amd64")
+
+	if err := g.Format(); err != nil {
+		t.Fatalf("expected Format to succeed, got %s", err.Error())
+	}
+
+	const want = "// Code generated by go-generator -type Stack; DO NOT EDIT.\n" +
+		"//\n" +
+		"// source: stack.go\n" +
+		"\n" +
+		"//go:build linux && amd64\n" +
+		"// +build linux,amd64\n" +
+		"\n" +
+		"package p\n\nvar X = 1\n"
+
+	if string(g.Data) != want {
+		t.Errorf("expected banner:\n%s\ngot:\n%s", want, g.Data)
+	}
+}
+
+func TestGeneratedFormatAnnotatesSyntaxError(t *testing.T) {
+	g := &Generated{
+		Data: []byte("package p\n\nvar X = \n"),
+	}
+
+	err := g.Format()
+	if err == nil {
+		t.Fatalf("expected Format to fail on invalid source")
+	}
+
+	if !strings.Contains(err.Error(), "3: var X = ") {
+		t.Errorf("expected error to include the offending line content, got %q", err.Error())
+	}
+}
+
+func TestGeneratedWriteFileKeepsRawOnFormatError(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out.go")
+
+	g := Generated{
+		DestLoc:              dest,
+		Data:                 []byte("package p\n\nvar X = \n"),
+		KeepRawOnFormatError: true,
+	}
+
+	if err := g.WriteFile(); err == nil {
+		t.Fatalf("expected WriteFile to fail on invalid source")
+	}
+
+	raw_loc := strings.TrimSuffix(dest, go_ext) + raw_ext
+
+	got, err := os.ReadFile(raw_loc)
+	if err != nil {
+		t.Fatalf("expected raw sibling to be written, got %s", err.Error())
+	}
+
+	if string(got) != string(g.Data) {
+		t.Errorf("expected raw sibling to contain %q, got %q", g.Data, got)
+	}
+
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("expected destination file not to be written")
+	}
+}
@@ -38,6 +38,14 @@ type CodeGenerator[T PackageNameSetter] struct {
 
 	// do_funcs is the list of functions to perform on the data before generating the code.
 	do_funcs []DoFunc[T]
+
+	// keep_raw_on_format_error tells the generated output to keep a '.go.raw'
+	// sibling of the destination file around whenever go/format.Source fails.
+	keep_raw_on_format_error bool
+
+	// imports tracks the imports requested by the template via the "imp"
+	// function registered by NewCodeGeneratorFromTemplate.
+	imports *Imports
 }
 
 // IsNil checks whether the code generator is nil or not.
@@ -66,6 +74,7 @@ func NewCodeGenerator[T PackageNameSetter](templ *template.Template) (*CodeGener
 	return &CodeGenerator[T]{
 		templ:    templ,
 		do_funcs: make([]DoFunc[T], 0),
+		imports:  NewImports(false, false),
 	}, nil
 }
 
@@ -79,8 +88,16 @@ func NewCodeGenerator[T PackageNameSetter](templ *template.Template) (*CodeGener
 // Returns:
 //   - *CodeGenerator: The code generator.
 //   - error: An error if template.Parse fails.
+//
+// The template may call {{ imp "some/import/path" }} to obtain the local
+// qualifier to use for that import; every tracked import is then rendered
+// into a single "import ( ... )" block wherever the template writes
+// ImportsPlaceholder. See SetImportGrouping and SetPruneUnusedImports to
+// control how that block is rendered.
 func NewCodeGeneratorFromTemplate[T PackageNameSetter](name, templ string) (*CodeGenerator[T], error) {
-	t, err := template.New(name).Parse(templ)
+	imports := NewImports(false, false)
+
+	t, err := template.New(name).Funcs(template.FuncMap{"imp": imports.Imp}).Parse(templ)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +105,7 @@ func NewCodeGeneratorFromTemplate[T PackageNameSetter](name, templ string) (*Cod
 	return &CodeGenerator[T]{
 		templ:    t,
 		do_funcs: make([]DoFunc[T], 0),
+		imports:  imports,
 	}, nil
 }
 
@@ -108,6 +126,63 @@ func (cg *CodeGenerator[T]) AddDoFunc(do_func DoFunc[T]) bool {
 	return true
 }
 
+// SetKeepRawOnFormatError controls whether a '.go.raw' sibling of the
+// destination file is kept around for debugging whenever the generated code
+// fails to format.
+//
+// Parameters:
+//   - keep: Whether to keep the raw file on a formatting error.
+//
+// Returns:
+//   - bool: True if the receiver is not nil, false otherwise.
+func (cg *CodeGenerator[T]) SetKeepRawOnFormatError(keep bool) bool {
+	if cg == nil {
+		return false
+	}
+
+	cg.keep_raw_on_format_error = keep
+
+	return true
+}
+
+// SetImportGrouping controls whether imports tracked via the template's "imp"
+// function are rendered with the standard library imports in their own
+// group, separated from third-party imports.
+//
+// Parameters:
+//   - group_std: Whether to group standard library imports separately.
+//
+// Returns:
+//   - bool: True if the receiver is not nil, false otherwise.
+func (cg *CodeGenerator[T]) SetImportGrouping(group_std bool) bool {
+	if cg == nil || cg.imports == nil {
+		return false
+	}
+
+	cg.imports.group_std = group_std
+
+	return true
+}
+
+// SetPruneUnusedImports controls whether imports tracked via the template's
+// "imp" function, but never referenced by the rendered body, are dropped
+// from the injected import block.
+//
+// Parameters:
+//   - prune: Whether to drop unused imports.
+//
+// Returns:
+//   - bool: True if the receiver is not nil, false otherwise.
+func (cg *CodeGenerator[T]) SetPruneUnusedImports(prune bool) bool {
+	if cg == nil || cg.imports == nil {
+		return false
+	}
+
+	cg.imports.prune_unused = prune
+
+	return true
+}
+
 // fix_import_dir takes a destination string and manipulates it to get the correct import path.
 //
 // Parameters:
@@ -135,6 +210,34 @@ func fix_import_dir(dest string) (string, error) {
 	return right, nil
 }
 
+// pkg_name_for_dir resolves the package name of the directory dir itself, as
+// opposed to fix_import_dir, which resolves the package name of the
+// directory a *file* destination lives in.
+//
+// Parameters:
+//   - dir: The output directory.
+//
+// Returns:
+//   - string: The package name.
+//   - error: An error if there is any.
+func pkg_name_for_dir(dir string) (string, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	base := filepath.Base(filepath.Clean(dir))
+	if base != "" && base != "." && base != string(filepath.Separator) {
+		return base, nil
+	}
+
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return "", err
+	}
+
+	return pkg.Name, nil
+}
+
 // fix_output_loc fixes the output location.
 //
 // Parameters:
@@ -217,7 +320,7 @@ func (cg CodeGenerator[T]) GenerateWithLoc(loc string, data T) (*Generated, erro
 	// we can remove the dependency on the Generater interface. Suggested to do so
 	// as part of the refactoring.
 
-	g := &Generated{}
+	g := &Generated{KeepRawOnFormatError: cg.keep_raw_on_format_error}
 
 	output_loc, err := fix_loc(loc)
 	if err != nil {
@@ -240,6 +343,8 @@ func (cg CodeGenerator[T]) GenerateWithLoc(loc string, data T) (*Generated, erro
 		}
 	}
 
+	cg.imports.reset()
+
 	var buff bytes.Buffer
 
 	err = cg.templ.Execute(&buff, data)
@@ -247,7 +352,7 @@ func (cg CodeGenerator[T]) GenerateWithLoc(loc string, data T) (*Generated, erro
 		return g, err
 	}
 
-	g.Data = buff.Bytes()
+	g.Data = inject_imports(buff.Bytes(), cg.imports)
 
 	return g, nil
 }
@@ -293,7 +398,7 @@ func (cg CodeGenerator[T]) Generate(o *OutputLocVal, default_file_name string, d
 	// we can remove the dependency on the Generater interface. Suggested to do so
 	// as part of the refactoring.
 
-	g := &Generated{}
+	g := &Generated{KeepRawOnFormatError: cg.keep_raw_on_format_error, Mode: o.Mode()}
 
 	output_loc, err := o.fix(default_file_name)
 	if err != nil {
@@ -316,6 +421,8 @@ func (cg CodeGenerator[T]) Generate(o *OutputLocVal, default_file_name string, d
 		}
 	}
 
+	cg.imports.reset()
+
 	var buff bytes.Buffer
 
 	err = cg.templ.Execute(&buff, data)
@@ -323,7 +430,7 @@ func (cg CodeGenerator[T]) Generate(o *OutputLocVal, default_file_name string, d
 		return g, err
 	}
 
-	g.Data = buff.Bytes()
+	g.Data = inject_imports(buff.Bytes(), cg.imports)
 
 	return g, nil
 }
@@ -14,3 +14,14 @@ func TestFixImportDir(t *testing.T) {
 		t.Errorf("FixImportDir failed: expected %s, got %s", "stack.go", fixed)
 	}
 }
+
+func TestPkgNameForDir(t *testing.T) {
+	name, err := pkg_name_for_dir("/tmp/drivecheck-out/mypkg")
+	if err != nil {
+		t.Fatalf("pkg_name_for_dir failed: %s", err.Error())
+	}
+
+	if name != "mypkg" {
+		t.Errorf("expected %q, got %q", "mypkg", name)
+	}
+}
@@ -0,0 +1,223 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ImportsPlaceholder is the token a template should include, on its own
+// line, where the import block tracked by the template's "imp" function
+// should be injected.
+const ImportsPlaceholder = "//go-generator:imports"
+
+// Imports tracks the imports requested by a template as it renders, via the
+// "imp" template function registered by NewCodeGeneratorFromTemplate.
+//
+// This mirrors GeneratedFile.Import, but operates over a single rendered
+// template's body instead of a Plugin's set of files.
+type Imports struct {
+	// paths is the set of import paths tracked so far, in the order they
+	// were first requested.
+	paths []string
+
+	// qualifiers maps an import path to the local qualifier it was assigned.
+	qualifiers map[string]string
+
+	// used_names tracks which local qualifiers have already been assigned.
+	used_names map[string]bool
+
+	// group_std, when true, renders standard library imports in their own
+	// group, separated from third-party imports.
+	group_std bool
+
+	// prune_unused, when true, makes Block omit tracked imports whose
+	// qualifier does not appear anywhere in the rendered body.
+	prune_unused bool
+}
+
+// NewImports creates a new, empty Imports tracker.
+//
+// Parameters:
+//   - group_std: Whether standard library imports should be rendered in
+//     their own group.
+//   - prune_unused: Whether imports unused in the rendered body should be
+//     dropped from the final block.
+//
+// Returns:
+//   - *Imports: The imports tracker. Never nil.
+func NewImports(group_std, prune_unused bool) *Imports {
+	return &Imports{
+		qualifiers:   make(map[string]string),
+		used_names:   make(map[string]bool),
+		group_std:    group_std,
+		prune_unused: prune_unused,
+	}
+}
+
+// Imp records that the rendered template depends on import_path and returns
+// the local qualifier to use to reference it. It is registered in the
+// template's FuncMap under the name "imp".
+//
+// Parameters:
+//   - import_path: The import path to track.
+//
+// Returns:
+//   - string: The local qualifier assigned to import_path.
+//
+// If import_path collides with a qualifier already assigned to a different
+// import (e.g. two packages named "runtime"), the later one is suffixed with
+// an increasing number ("runtime2", "runtime3", ...).
+func (imp *Imports) Imp(import_path string) string {
+	if imp == nil {
+		return ""
+	}
+
+	if qualifier, ok := imp.qualifiers[import_path]; ok {
+		return qualifier
+	}
+
+	base := path.Base(import_path)
+	qualifier := base
+
+	for n := 2; imp.used_names[qualifier]; n++ {
+		qualifier = base + strconv.Itoa(n)
+	}
+
+	imp.paths = append(imp.paths, import_path)
+	imp.qualifiers[import_path] = qualifier
+	imp.used_names[qualifier] = true
+
+	return qualifier
+}
+
+// is_std reports whether import_path looks like a standard library import,
+// i.e. its first path segment has no dot in it.
+func is_std(import_path string) bool {
+	first, _, _ := strings.Cut(import_path, "/")
+
+	return !strings.Contains(first, ".")
+}
+
+// Block renders the tracked imports as a Go "import ( ... )" block.
+//
+// Parameters:
+//   - body: The rendered template body, used to decide which imports are
+//     actually referenced when imp.prune_unused is set.
+//
+// Returns:
+//   - string: The import block, or an empty string if there is nothing to
+//     import.
+func (imp *Imports) Block(body []byte) string {
+	if imp == nil || len(imp.paths) == 0 {
+		return ""
+	}
+
+	paths := make([]string, 0, len(imp.paths))
+
+	for _, p := range imp.paths {
+		if imp.prune_unused && !bytes.Contains(body, []byte(imp.qualifiers[p]+".")) {
+			continue
+		}
+
+		paths = append(paths, p)
+	}
+
+	if len(paths) == 0 {
+		return ""
+	}
+
+	sort.Strings(paths)
+
+	render := func(p string) string {
+		qualifier := imp.qualifiers[p]
+
+		if qualifier == path.Base(p) {
+			return fmt.Sprintf("\t%q\n", p)
+		}
+
+		return fmt.Sprintf("\t%s %q\n", qualifier, p)
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("import (\n")
+
+	if imp.group_std {
+		var std, rest []string
+
+		for _, p := range paths {
+			if is_std(p) {
+				std = append(std, p)
+			} else {
+				rest = append(rest, p)
+			}
+		}
+
+		for _, p := range std {
+			sb.WriteString(render(p))
+		}
+
+		if len(std) > 0 && len(rest) > 0 {
+			sb.WriteString("\n")
+		}
+
+		for _, p := range rest {
+			sb.WriteString(render(p))
+		}
+	} else {
+		for _, p := range paths {
+			sb.WriteString(render(p))
+		}
+	}
+
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+// reset clears the tracker so that it can be reused for a new render.
+func (imp *Imports) reset() {
+	if imp == nil {
+		return
+	}
+
+	imp.paths = imp.paths[:0]
+
+	for k := range imp.qualifiers {
+		delete(imp.qualifiers, k)
+	}
+
+	for k := range imp.used_names {
+		delete(imp.used_names, k)
+	}
+}
+
+// inject_imports replaces the first occurrence of ImportsPlaceholder in src
+// with imp's rendered import block. If the placeholder is not present, src is
+// returned unchanged.
+//
+// Parameters:
+//   - src: The rendered template body.
+//   - imp: The imports tracker to render.
+//
+// Returns:
+//   - []byte: The source with the import block injected.
+func inject_imports(src []byte, imp *Imports) []byte {
+	idx := bytes.Index(src, []byte(ImportsPlaceholder))
+	if idx < 0 {
+		return src
+	}
+
+	block := imp.Block(src)
+
+	out := make([]byte, 0, len(src)-len(ImportsPlaceholder)+len(block))
+	out = append(out, src[:idx]...)
+	out = append(out, block...)
+	out = append(out, src[idx+len(ImportsPlaceholder):]...)
+
+	return out
+}
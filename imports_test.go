@@ -0,0 +1,31 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportsBlockAliasesCollisions(t *testing.T) {
+	imp := NewImports(false, false)
+
+	first := imp.Imp("k8s.io/apimachinery/pkg/runtime")
+	second := imp.Imp("runtime")
+
+	if first != "runtime" {
+		t.Errorf("expected first import to keep its base qualifier, got %q", first)
+	}
+
+	if second != "runtime2" {
+		t.Errorf("expected colliding import to be aliased to runtime2, got %q", second)
+	}
+
+	block := imp.Block(nil)
+
+	if !strings.Contains(block, `"k8s.io/apimachinery/pkg/runtime"`) {
+		t.Errorf("expected block to contain the first import path, got:\n%s", block)
+	}
+
+	if !strings.Contains(block, `runtime2 "runtime"`) {
+		t.Errorf("expected block to alias the colliding import, got:\n%s", block)
+	}
+}
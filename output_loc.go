@@ -0,0 +1,208 @@
+package generator
+
+import (
+	"flag"
+	"fmt"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// Mode is the output mode of an OutputLocVal, controlling what
+// Generated.WriteFile does with the generated code once it has been
+// formatted.
+type Mode int
+
+const (
+	// WriteMode writes the generated code to DestLoc. This is the default.
+	WriteMode Mode = iota
+
+	// PrintMode writes the generated code to stdout, preceded by a banner
+	// naming the destination file, instead of writing it to disk.
+	PrintMode
+
+	// DiffMode computes a unified diff between the generated code and the
+	// file currently on disk and prints it. Generated.WriteFile returns
+	// ErrDiffers if they differ.
+	DiffMode
+
+	// CheckMode is like DiffMode but prints nothing: it is meant for
+	// 'go generate' CI jobs that only need to know whether the committed
+	// generated files are up to date. Generated.WriteFile returns
+	// ErrDiffers if they differ.
+	CheckMode
+)
+
+// String implements the flag.Value interface.
+func (m Mode) String() string {
+	switch m {
+	case WriteMode:
+		return "write"
+	case PrintMode:
+		return "print"
+	case DiffMode:
+		return "diff"
+	case CheckMode:
+		return "check"
+	default:
+		return "write"
+	}
+}
+
+// parse_mode parses the textual representation of a Mode, as accepted by the
+// -mode flag.
+func parse_mode(str string) (Mode, error) {
+	switch str {
+	case "", "write":
+		return WriteMode, nil
+	case "print":
+		return PrintMode, nil
+	case "diff":
+		return DiffMode, nil
+	case "check":
+		return CheckMode, nil
+	default:
+		return WriteMode, fmt.Errorf("invalid mode %q: must be one of write, print, diff, check", str)
+	}
+}
+
+// OutputLocVal is a flag.Value that holds the output location of the
+// generated code together with the mode it should be written in.
+type OutputLocVal struct {
+	// loc is the output location set by the user, or the default_loc passed
+	// to NewOutputFlag if the user did not set one.
+	loc string
+
+	// required indicates whether the user must set the -o flag.
+	required bool
+
+	// mode is the mode the output should be written in.
+	mode Mode
+}
+
+// NewOutputFlag creates a new OutputLocVal and registers the -o and -mode
+// flags for it on flag.CommandLine.
+//
+// Parameters:
+//   - default_loc: The output location to use if the user does not set one.
+//   - required: Whether the -o flag must be set by the user.
+//
+// Returns:
+//   - *OutputLocVal: The output location flag. Never nil.
+func NewOutputFlag(default_loc string, required bool) *OutputLocVal {
+	o := &OutputLocVal{
+		loc:      default_loc,
+		required: required,
+		mode:     WriteMode,
+	}
+
+	o.Register(flag.CommandLine)
+
+	return o
+}
+
+// String implements the flag.Value interface.
+func (o *OutputLocVal) String() string {
+	if o == nil {
+		return ""
+	}
+
+	return o.loc
+}
+
+// Set implements the flag.Value interface.
+func (o *OutputLocVal) Set(value string) error {
+	if o == nil {
+		return gers.NewErrInvalidParameter("OutputLocVal.Set()", "receiver must not be nil")
+	}
+
+	o.loc = value
+
+	return nil
+}
+
+// Mode returns the mode the output should be written in.
+//
+// Returns:
+//   - Mode: The output mode.
+func (o *OutputLocVal) Mode() Mode {
+	if o == nil {
+		return WriteMode
+	}
+
+	return o.mode
+}
+
+// mode_flag adapts OutputLocVal's mode to the flag.Value interface so that
+// -mode can be registered on a flag.FlagSet alongside -o.
+type mode_flag struct {
+	o *OutputLocVal
+}
+
+// String implements the flag.Value interface.
+func (m mode_flag) String() string {
+	if m.o == nil {
+		return WriteMode.String()
+	}
+
+	return m.o.mode.String()
+}
+
+// Set implements the flag.Value interface.
+func (m mode_flag) Set(value string) error {
+	mode, err := parse_mode(value)
+	if err != nil {
+		return err
+	}
+
+	m.o.mode = mode
+
+	return nil
+}
+
+// Register registers the -o and -mode flags on fs.
+//
+// Parameters:
+//   - fs: The flag set to register the flags on.
+func (o *OutputLocVal) Register(fs *flag.FlagSet) {
+	if o == nil || fs == nil {
+		return
+	}
+
+	fs.Var(o, "o", "the output location of the generated code")
+	fs.Var(mode_flag{o: o}, "mode", "the output mode: write, print, diff, or check")
+}
+
+// fix resolves the final output location, applying default_file_name when the
+// user did not set one via the -o flag.
+//
+// Parameters:
+//   - default_file_name: The file name to fall back to.
+//
+// Returns:
+//   - string: The resolved output location.
+//   - error: An error if no location could be resolved, or if fix_loc fails.
+//
+// Errors:
+//   - *common.ErrInvalidUsage: If the -o flag is required but was not set.
+//   - error: Any other error that may have occurred.
+func (o *OutputLocVal) fix(default_file_name string) (string, error) {
+	if o == nil {
+		return "", gers.NewErrInvalidParameter("OutputLocVal.fix()", "receiver must not be nil")
+	}
+
+	loc := o.loc
+
+	if loc == "" {
+		if o.required {
+			return "", gers.NewErrInvalidUsage(
+				"OutputLocVal.fix()",
+				"the -o flag was not set",
+				"Please set the -o flag before calling flag.Parse().",
+			)
+		}
+
+		loc = default_file_name
+	}
+
+	return fix_loc(loc)
+}
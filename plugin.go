@@ -0,0 +1,407 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// Ident is a qualified Go identifier. It is used to reference a symbol that
+// may be defined in a different generated file than the one referencing it.
+//
+// Ident is built by hand for this: e.g. Ident{GoName: "Stack", GoImportPath:
+// "example.com/stack"} references the Stack symbol declared in that package,
+// however it is generated. It is not what GeneratedFile.Import returns; see
+// PackageIdent for that.
+type Ident struct {
+	// GoName is the unqualified name of the identifier (e.g. "Stack").
+	GoName string
+
+	// GoImportPath is the import path the identifier is declared in. If
+	// empty, the identifier is assumed to live in the file that references it.
+	GoImportPath string
+}
+
+// PackageIdent is the local qualifier GeneratedFile.Import assigned to a
+// tracked import path. Passing one to GeneratedFile.P prints the bare
+// qualifier (e.g. "fmt"), not a qualified reference into that package - for
+// that, build an Ident by hand.
+type PackageIdent struct {
+	// GoName is the local qualifier, e.g. "fmt", or "runtime2" if aliased to
+	// avoid a collision.
+	GoName string
+
+	// GoImportPath is the import path this qualifier was assigned to.
+	GoImportPath string
+}
+
+// GeneratedFile is a single file being built up as part of a Plugin run.
+type GeneratedFile struct {
+	// name is the file name (relative to the plugin's output root) of this
+	// generated file.
+	name string
+
+	// buf accumulates the source of the file as P is called.
+	buf strings.Builder
+
+	// imports maps an import path to the local qualifier it was assigned.
+	imports map[string]string
+
+	// used_names tracks which local qualifiers have already been assigned, so
+	// that collisions can be resolved deterministically.
+	used_names map[string]bool
+
+	// header is extra text to include in the header comment of this file, set
+	// via SetHeader. See Generated.SetHeader.
+	header string
+
+	// build_tags holds the build constraint tags to render for this file, set
+	// via SetBuildTags. See Generated.SetBuildTags.
+	build_tags []string
+
+	// generate_directive records the invoking `go generate` command for this
+	// file, set via SetGenerateDirective. See Generated.SetGenerateDirective.
+	generate_directive string
+}
+
+// SetHeader sets extra text to include in the header comment of this file,
+// alongside the canonical "Code generated ...; DO NOT EDIT." banner.
+//
+// Parameters:
+//   - text: The text to include. Each line is rendered as its own comment
+//     line.
+func (gf *GeneratedFile) SetHeader(text string) {
+	if gf == nil {
+		return
+	}
+
+	gf.header = text
+}
+
+// SetBuildTags sets the build constraint tags to prepend to this file as
+// //go:build and // +build lines.
+//
+// Parameters:
+//   - tags: The build tags to require, combined with AND.
+func (gf *GeneratedFile) SetBuildTags(tags ...string) {
+	if gf == nil {
+		return
+	}
+
+	gf.build_tags = tags
+}
+
+// SetGenerateDirective records the invoking `go generate` command for this
+// file, used to name the generator in the header banner.
+//
+// Parameters:
+//   - cmd: The invoking command, e.g. "go-generator -type Stack".
+func (gf *GeneratedFile) SetGenerateDirective(cmd string) {
+	if gf == nil {
+		return
+	}
+
+	gf.generate_directive = cmd
+}
+
+// new_generated_file creates a new, empty generated file with the given name.
+func new_generated_file(name string) *GeneratedFile {
+	return &GeneratedFile{
+		name:       name,
+		imports:    make(map[string]string),
+		used_names: make(map[string]bool),
+	}
+}
+
+// P prints one line to the file, similarly to protogen's GeneratedFile.P: each
+// argument is converted with fmt.Sprint and concatenated, and a trailing
+// newline is appended.
+//
+// Parameters:
+//   - args: The values to print.
+func (gf *GeneratedFile) P(args ...any) {
+	if gf == nil {
+		return
+	}
+
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case Ident:
+			gf.buf.WriteString(gf.QualifiedGoIdent(v))
+		case PackageIdent:
+			gf.buf.WriteString(v.GoName)
+		default:
+			fmt.Fprint(&gf.buf, v)
+		}
+	}
+
+	gf.buf.WriteByte('\n')
+}
+
+// Import records that the file depends on the given import path and returns
+// the local qualifier the file should use to reference it.
+//
+// Parameters:
+//   - import_path: The import path to track.
+//
+// Returns:
+//   - PackageIdent: The local qualifier assigned to import_path. Pass it
+//     directly to GeneratedFile.P to print the bare qualifier; to reference
+//     a specific symbol in the package, build an Ident by hand instead.
+//
+// If import_path collides with a qualifier already assigned to a different
+// import (e.g. two packages named "runtime"), the later one is suffixed with
+// an increasing number ("runtime2", "runtime3", ...), mirroring how goimports
+// disambiguates colliding packages.
+//
+// Every import tracked this way is rendered into the file's import block by
+// content, without any further action required from the caller.
+func (gf *GeneratedFile) Import(import_path string) PackageIdent {
+	if gf == nil {
+		return PackageIdent{}
+	}
+
+	if qualifier, ok := gf.imports[import_path]; ok {
+		return PackageIdent{GoName: qualifier, GoImportPath: import_path}
+	}
+
+	base := path.Base(import_path)
+
+	qualifier := base
+
+	for n := 2; gf.used_names[qualifier]; n++ {
+		qualifier = base + strconv.Itoa(n)
+	}
+
+	gf.imports[import_path] = qualifier
+	gf.used_names[qualifier] = true
+
+	return PackageIdent{GoName: qualifier, GoImportPath: import_path}
+}
+
+// QualifiedGoIdent returns the string to use to reference ident from within
+// this file, importing ident.GoImportPath as a side effect if it is set and
+// not already tracked.
+//
+// Parameters:
+//   - ident: The identifier to reference.
+//
+// Returns:
+//   - string: The qualified reference, e.g. "stack.New" or just "New" if
+//     ident has no import path.
+func (gf *GeneratedFile) QualifiedGoIdent(ident Ident) string {
+	if gf == nil {
+		return ident.GoName
+	}
+
+	if ident.GoImportPath == "" {
+		return ident.GoName
+	}
+
+	qualifier := gf.Import(ident.GoImportPath)
+
+	return qualifier.GoName + "." + ident.GoName
+}
+
+// content returns the accumulated, unformatted source of the file, with the
+// imports tracked via Import rendered into an "import ( ... )" block.
+//
+// The block is inserted directly after the file's first line, which is
+// assumed to be the "package X" clause GeneratedFile.P was used to write; this
+// mirrors how protoc-gen-go's GeneratedFile inserts its own import block.
+func (gf *GeneratedFile) content() []byte {
+	body := []byte(gf.buf.String())
+
+	imp := &Imports{
+		paths:      make([]string, 0, len(gf.imports)),
+		qualifiers: gf.imports,
+		used_names: gf.used_names,
+	}
+
+	for import_path := range gf.imports {
+		imp.paths = append(imp.paths, import_path)
+	}
+
+	block := imp.Block(body)
+	if block == "" {
+		return body
+	}
+
+	idx := bytes.IndexByte(body, '\n')
+	if idx < 0 {
+		idx = len(body)
+	} else {
+		idx++
+	}
+
+	out := make([]byte, 0, len(body)+len(block)+2)
+	out = append(out, body[:idx]...)
+	out = append(out, '\n')
+	out = append(out, block...)
+	out = append(out, '\n')
+	out = append(out, body[idx:]...)
+
+	return out
+}
+
+// Plugin is a single generation run that may emit several files sharing the
+// same output root and package-name resolution, modeled after protoc-gen-go's
+// protogen.Plugin.
+type Plugin struct {
+	// root is the output root directory all files are written relative to.
+	root string
+
+	// pkg_name is the package name shared by every file produced in this run,
+	// resolved once via pkg_name_for_dir.
+	pkg_name string
+
+	// files is the set of files created during this run, keyed by name to
+	// reject duplicates.
+	files map[string]*GeneratedFile
+
+	// order preserves the order in which files were requested, for
+	// deterministic iteration regardless of map ordering.
+	order []string
+
+	// preserve is the set of paths, relative to root, that the write phase
+	// must refuse to overwrite.
+	preserve []string
+}
+
+// Preserve declares that the given paths, relative to the plugin's output
+// root, are owned by the user (e.g. a hand-edited go.mod or sibling source
+// file) and must be left untouched by Result.WriteFiles.
+//
+// Parameters:
+//   - paths: The paths to preserve.
+func (p *Plugin) Preserve(paths ...string) {
+	if p == nil {
+		return
+	}
+
+	p.preserve = append(p.preserve, paths...)
+}
+
+// PackageName returns the package name shared by every file produced in this
+// run.
+//
+// Returns:
+//   - string: The package name.
+func (p *Plugin) PackageName() string {
+	if p == nil {
+		return ""
+	}
+
+	return p.pkg_name
+}
+
+// NewGeneratedFile creates (or returns, if already created) the named file
+// for this plugin run.
+//
+// Parameters:
+//   - name: The file name, relative to the plugin's output root.
+//
+// Returns:
+//   - *GeneratedFile: The generated file, or nil if the receiver is nil.
+func (p *Plugin) NewGeneratedFile(name string) *GeneratedFile {
+	if p == nil {
+		return nil
+	}
+
+	if gf, ok := p.files[name]; ok {
+		return gf
+	}
+
+	gf := new_generated_file(name)
+
+	p.files[name] = gf
+	p.order = append(p.order, name)
+
+	return gf
+}
+
+// Run executes fn against a fresh Plugin rooted at root and collects every
+// file the callback created, in deterministic, lexicographic order by file
+// name.
+//
+// Parameters:
+//   - o: The output mode to apply to every produced Generated. May be nil, in
+//     which case every file is written to disk (the default Mode).
+//   - root: The output root directory every produced Generated is written
+//     relative to.
+//   - fn: The callback that populates the plugin's files.
+//
+// Returns:
+//   - *Result: The generated files, one per call to Plugin.NewGeneratedFile,
+//     sorted by name, together with any paths declared via Plugin.Preserve.
+//   - error: An error if fn fails, or if root cannot be resolved to a package
+//     name.
+//
+// Unlike Generate and GenerateWithLoc, Run does not execute cg.templ or
+// cg.do_funcs: those only apply to the single-template API, since there is no
+// single T to run them against here. The callback is expected to build its
+// files itself via GeneratedFile.P.
+func (cg *CodeGenerator[T]) Run(o *OutputLocVal, root string, fn func(*Plugin) error) (*Result, error) {
+	if fn == nil {
+		err := gers.NewErrInvalidParameter("CodeGenerator.Run()", "fn must not be nil")
+
+		return nil, err
+	}
+
+	pkg_name, err := pkg_name_for_dir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fix import path: %w", err)
+	}
+
+	p := &Plugin{
+		root:     root,
+		pkg_name: pkg_name,
+		files:    make(map[string]*GeneratedFile),
+	}
+
+	err = fn(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var mode Mode
+	if o != nil {
+		mode = o.Mode()
+	}
+
+	names := make([]string, len(p.order))
+	copy(names, p.order)
+	sort.Strings(names)
+
+	files := make([]*Generated, 0, len(names))
+
+	for _, name := range names {
+		gf := p.files[name]
+
+		g := &Generated{
+			DestLoc:              filepath.Join(p.root, gf.name),
+			Data:                 gf.content(),
+			KeepRawOnFormatError: cg.keep_raw_on_format_error,
+			Mode:                 mode,
+		}
+
+		g.SetHeader(gf.header)
+		g.SetBuildTags(gf.build_tags...)
+		g.SetGenerateDirective(gf.generate_directive)
+
+		files = append(files, g)
+	}
+
+	preserve := make([]string, len(p.preserve))
+	for i, rel := range p.preserve {
+		preserve[i] = filepath.Join(p.root, rel)
+	}
+
+	return &Result{Files: files, Preserve: preserve}, nil
+}
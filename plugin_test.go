@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"bytes"
+	"go/format"
+	"path/filepath"
+	"testing"
+	"text/template"
+)
+
+type dummyData struct {
+	pkg string
+}
+
+func (d *dummyData) SetPackageName(pkg_name string) {
+	d.pkg = pkg_name
+}
+
+func TestPluginRunRendersTrackedImports(t *testing.T) {
+	templ, err := template.New("x").Parse("")
+	if err != nil {
+		t.Fatalf("failed to parse template: %s", err.Error())
+	}
+
+	cg, err := NewCodeGenerator[*dummyData](templ)
+	if err != nil {
+		t.Fatalf("NewCodeGenerator failed: %s", err.Error())
+	}
+
+	o := &OutputLocVal{mode: WriteMode}
+
+	root := filepath.Join(t.TempDir(), "mypkg")
+
+	result, err := cg.Run(o, root, func(p *Plugin) error {
+		if p.PackageName() != "mypkg" {
+			t.Errorf("expected PackageName %q, got %q", "mypkg", p.PackageName())
+		}
+
+		f := p.NewGeneratedFile("out.go")
+
+		f.P("package ", p.PackageName())
+		f.P()
+
+		ident := f.Import("fmt")
+
+		f.P("var Greeting = ", ident.GoName, `.Sprintf("hi")`)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %s", err.Error())
+	}
+
+	if len(result.Files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(result.Files))
+	}
+
+	data := result.Files[0].Data
+
+	if !bytes.Contains(data, []byte(`"fmt"`)) {
+		t.Errorf("expected generated file to import fmt, got:\n%s", data)
+	}
+
+	if _, err := format.Source(data); err != nil {
+		t.Errorf("generated file is not syntactically valid Go: %s\n%s", err.Error(), data)
+	}
+}
+
+func TestNewGeneratedFileNilReceiver(t *testing.T) {
+	var p *Plugin
+
+	if gf := p.NewGeneratedFile("out.go"); gf != nil {
+		t.Errorf("expected nil GeneratedFile from a nil *Plugin, got %v", gf)
+	}
+}
+
+func TestGeneratedFileCrossFileIdentVsPackageIdent(t *testing.T) {
+	f := new_generated_file("out.go")
+
+	f.P("package mypkg")
+	f.P()
+
+	// PackageIdent, as returned by Import, prints as the bare qualifier.
+	pkg := f.Import("fmt")
+	f.P("var _ = ", pkg, `.Sprintf("hi")`)
+
+	// An Ident built by hand references a symbol in another package.
+	stack := Ident{GoName: "Stack", GoImportPath: "example.com/stack"}
+	f.P("var _ = ", stack, "{}")
+
+	data := f.content()
+
+	if !bytes.Contains(data, []byte("fmt.Sprintf")) {
+		t.Errorf("expected generated file to reference fmt.Sprintf, got:\n%s", data)
+	}
+
+	if !bytes.Contains(data, []byte("stack.Stack{}")) {
+		t.Errorf("expected generated file to reference stack.Stack, got:\n%s", data)
+	}
+
+	if _, err := format.Source(data); err != nil {
+		t.Errorf("generated file is not syntactically valid Go: %s\n%s", err.Error(), data)
+	}
+}
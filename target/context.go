@@ -0,0 +1,135 @@
+package target
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+
+	gers "github.com/PlayerR9/go-errors"
+)
+
+// load_mode is the set of packages.Load information needed to walk type
+// declarations together with their doc comments.
+const load_mode = packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo |
+	packages.NeedSyntax | packages.NeedDeps
+
+// Context is fed to a generator.CodeGenerator template once per type matched
+// by a Target.
+type Context struct {
+	// Package is the package the matched type was declared in.
+	Package *packages.Package
+
+	// Type is the matched type.
+	Type *TypeInfo
+
+	// PackageName is the package name the generated code should declare. It
+	// is overwritten by generator.CodeGenerator.Generate/GenerateWithLoc via
+	// SetPackageName.
+	PackageName string
+}
+
+// SetPackageName implements the generator.PackageNameSetter interface.
+//
+// Parameters:
+//   - pkg_name: The package name to use for the generated code.
+func (c *Context) SetPackageName(pkg_name string) {
+	c.PackageName = pkg_name
+}
+
+// Load loads the Go packages matching patterns, resolved relative to dir.
+//
+// Parameters:
+//   - dir: The directory to resolve patterns relative to.
+//   - patterns: The package patterns to load, as accepted by go/packages.
+//
+// Returns:
+//   - []*packages.Package: The loaded packages.
+//   - error: An error of type *common.ErrInvalidParameter if patterns is
+//     empty, or any error encountered while loading the packages.
+func Load(dir string, patterns ...string) ([]*packages.Package, error) {
+	if len(patterns) == 0 {
+		err := gers.NewErrInvalidParameter("Load()", "patterns must not be empty")
+
+		return nil, err
+	}
+
+	cfg := &packages.Config{
+		Dir:  dir,
+		Mode: load_mode,
+	}
+
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("errors were encountered while loading packages %v", patterns)
+	}
+
+	return pkgs, nil
+}
+
+// FindMatches walks every type declared in pkgs and returns a Context for
+// each one that satisfies at least one of targets.
+//
+// Parameters:
+//   - pkgs: The packages to walk, as returned by Load.
+//   - targets: The targets to match against.
+//
+// Returns:
+//   - []*Context: One Context per matched type.
+//   - error: An error if any occurred.
+func FindMatches(pkgs []*packages.Package, targets ...Target) ([]*Context, error) {
+	var out []*Context
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				gen_decl, ok := decl.(*ast.GenDecl)
+				if !ok || gen_decl.Tok != token.TYPE {
+					continue
+				}
+
+				doc := gen_decl.Doc.Text()
+
+				for _, spec := range gen_decl.Specs {
+					type_spec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+
+					spec_doc := doc
+					if type_spec.Doc != nil {
+						spec_doc = type_spec.Doc.Text()
+					}
+
+					obj, ok := pkg.TypesInfo.Defs[type_spec.Name]
+					if !ok || obj == nil {
+						continue
+					}
+
+					named, ok := obj.Type().(*types.Named)
+					if !ok {
+						continue
+					}
+
+					info := build_type_info(pkg.Name, pkg.PkgPath, type_spec.Name.Name, spec_doc, named)
+
+					for _, t := range targets {
+						if t.Matches(info) {
+							out = append(out, &Context{Package: pkg, Type: info})
+
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,115 @@
+// Package target loads Go packages via golang.org/x/tools/go/packages, walks
+// their declared types, and matches them against user-registered Targets so
+// that a generator.CodeGenerator can run its template once per matching type.
+//
+// It is inspired by k8s.io/gengo: instead of hand-writing which types a
+// generator cares about, a Target describes the selection criteria (a
+// "+generate:<tag>" doc comment, interface satisfaction, or a name pattern)
+// and the framework does the walking.
+package target
+
+import (
+	"go/types"
+	"regexp"
+	"strings"
+)
+
+// Target describes which types a generator is interested in. A type must
+// satisfy every non-zero field to match.
+type Target struct {
+	// Tag, if not empty, requires a "+generate:<tag>" comment somewhere in
+	// the type's doc comment, e.g. Tag "stack" matches "+generate:stack".
+	Tag string
+
+	// Implements, if not nil, requires the type (or a pointer to it) to
+	// satisfy this interface.
+	Implements *types.Interface
+
+	// NamePattern, if not nil, requires the type's name to match this
+	// regular expression.
+	NamePattern *regexp.Regexp
+}
+
+// Matches reports whether info satisfies every criterion set on t.
+//
+// Parameters:
+//   - info: The type to check.
+//
+// Returns:
+//   - bool: True if info matches, false otherwise.
+func (t Target) Matches(info *TypeInfo) bool {
+	if info == nil {
+		return false
+	}
+
+	if t.Tag != "" && !strings.Contains(info.Doc, "+generate:"+t.Tag) {
+		return false
+	}
+
+	if t.Implements != nil {
+		if !types.Implements(info.Type, t.Implements) && !types.Implements(types.NewPointer(info.Type), t.Implements) {
+			return false
+		}
+	}
+
+	if t.NamePattern != nil && !t.NamePattern.MatchString(info.Name) {
+		return false
+	}
+
+	return true
+}
+
+// TypeInfo exposes the fields, methods, generics, and doc comment of a
+// declared type, modeled after k8s.io/gengo's types.Type.
+type TypeInfo struct {
+	// Name is the type's identifier.
+	Name string
+
+	// PackageName is the name of the package the type is declared in.
+	PackageName string
+
+	// PackagePath is the import path of the package the type is declared in.
+	PackagePath string
+
+	// Doc is the type's doc comment, with comment markers stripped.
+	Doc string
+
+	// Type is the underlying go/types.Type of the declaration.
+	Type *types.Named
+
+	// TypeParams holds the type's generic type parameters, or nil if it is
+	// not generic.
+	TypeParams *types.TypeParamList
+
+	// Fields is the list of fields declared on the type, for struct types.
+	Fields []*types.Var
+
+	// Methods is the list of methods declared directly on the type, not
+	// counting methods promoted via embedding.
+	Methods []*types.Func
+}
+
+// build_type_info assembles a TypeInfo for a named type declared in pkg_name
+// (pkg_path), with the given doc comment.
+func build_type_info(pkg_name, pkg_path, name, doc string, named *types.Named) *TypeInfo {
+	info := &TypeInfo{
+		Name:        name,
+		PackageName: pkg_name,
+		PackagePath: pkg_path,
+		Doc:         strings.TrimSpace(doc),
+		Type:        named,
+		TypeParams:  named.TypeParams(),
+	}
+
+	if strct, ok := named.Underlying().(*types.Struct); ok {
+		for i := 0; i < strct.NumFields(); i++ {
+			info.Fields = append(info.Fields, strct.Field(i))
+		}
+	}
+
+	for i := 0; i < named.NumMethods(); i++ {
+		info.Methods = append(info.Methods, named.Method(i))
+	}
+
+	return info
+}
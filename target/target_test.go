@@ -0,0 +1,41 @@
+package target
+
+import (
+	"go/types"
+	"regexp"
+	"testing"
+)
+
+func TestTargetMatches(t *testing.T) {
+	pkg := types.NewPackage("example.com/stack", "stack")
+	strct := types.NewStruct(nil, nil)
+
+	name := types.NewTypeName(0, pkg, "Stack", nil)
+	named := types.NewNamed(name, strct, nil)
+
+	info := &TypeInfo{
+		Name: "Stack",
+		Doc:  "Stack is a LIFO container.\n+generate:stack\n",
+		Type: named,
+	}
+
+	if !(Target{Tag: "stack"}).Matches(info) {
+		t.Errorf("expected Tag %q to match doc %q", "stack", info.Doc)
+	}
+
+	if (Target{Tag: "queue"}).Matches(info) {
+		t.Errorf("expected Tag %q not to match doc %q", "queue", info.Doc)
+	}
+
+	if !(Target{NamePattern: regexp.MustCompile("^Sta")}).Matches(info) {
+		t.Errorf("expected NamePattern to match name %q", info.Name)
+	}
+
+	if (Target{NamePattern: regexp.MustCompile("^Que")}).Matches(info) {
+		t.Errorf("expected NamePattern not to match name %q", info.Name)
+	}
+
+	if (Target{}).Matches(nil) {
+		t.Errorf("expected a nil TypeInfo not to match")
+	}
+}
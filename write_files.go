@@ -0,0 +1,150 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Result is the output of a CodeGenerator.Run invocation: the files it
+// produced, plus any paths under the output root that must be left
+// untouched.
+type Result struct {
+	// Files is the set of generated files, in deterministic, lexicographic
+	// order by destination.
+	Files []*Generated
+
+	// Preserve is the set of paths that WriteFiles must refuse to overwrite,
+	// as declared via Plugin.Preserve.
+	Preserve []string
+}
+
+// WriteFiles emits every file in r according to its own Mode.
+//
+// Files in PrintMode, DiffMode, or CheckMode are delegated to their own
+// Generated.WriteFile, since those modes never touch more than the file
+// itself; every ErrDiffers they report is collected so that all of them are
+// printed/reported rather than stopping at the first.
+//
+// Files in WriteMode (the default) are committed as a single, all-or-nothing
+// operation: every such file is staged (formatted, in memory) before any of
+// them is committed to disk via atomic_write, and if committing any file
+// fails, every file already committed in this call is rolled back to the
+// state it was in beforehand.
+//
+// Returns:
+//   - error: An error if occurred. A destination colliding with a path in
+//     r.Preserve is rejected before anything is written.
+func (r *Result) WriteFiles() error {
+	if r == nil || len(r.Files) == 0 {
+		return nil
+	}
+
+	preserved := make(map[string]bool, len(r.Preserve))
+
+	for _, p := range r.Preserve {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+
+		preserved[abs] = true
+	}
+
+	for _, g := range r.Files {
+		abs, err := filepath.Abs(g.DestLoc)
+		if err != nil {
+			return err
+		}
+
+		if preserved[abs] {
+			return fmt.Errorf("refusing to overwrite preserved file %s", g.DestLoc)
+		}
+	}
+
+	var write_mode_files []*Generated
+	var diff_err error
+
+	for _, g := range r.Files {
+		if g.Mode != WriteMode {
+			if err := g.WriteFile(); err != nil {
+				if errors.Is(err, ErrDiffers) {
+					diff_err = err
+				} else {
+					return fmt.Errorf("failed to write %s: %w", g.DestLoc, err)
+				}
+			}
+
+			continue
+		}
+
+		write_mode_files = append(write_mode_files, g)
+	}
+
+	staged := make([][]byte, len(write_mode_files))
+
+	for i, g := range write_mode_files {
+		if err := g.format_with_raw_fallback(); err != nil {
+			return fmt.Errorf("failed to format %s: %w", g.DestLoc, err)
+		}
+
+		staged[i] = g.Data
+	}
+
+	var backups []file_backup
+
+	rollback := func() {
+		for _, b := range backups {
+			b.restore()
+		}
+	}
+
+	for i, g := range write_mode_files {
+		b, err := backup_file(g.DestLoc)
+		if err != nil {
+			rollback()
+			return err
+		}
+
+		if err := atomic_write(g.DestLoc, staged[i]); err != nil {
+			rollback()
+			return err
+		}
+
+		backups = append(backups, b)
+	}
+
+	return diff_err
+}
+
+// file_backup records the prior state of a file so that it can be restored
+// if a later write in the same WriteFiles call fails.
+type file_backup struct {
+	loc     string
+	existed bool
+	data    []byte
+}
+
+// backup_file captures the current content of loc, if any.
+func backup_file(loc string) (file_backup, error) {
+	data, err := os.ReadFile(loc)
+	if err == nil {
+		return file_backup{loc: loc, existed: true, data: data}, nil
+	}
+
+	if os.IsNotExist(err) {
+		return file_backup{loc: loc}, nil
+	}
+
+	return file_backup{}, err
+}
+
+// restore puts loc back the way it was when the backup was taken.
+func (b file_backup) restore() {
+	if b.existed {
+		_ = atomic_write(b.loc, b.data)
+	} else {
+		_ = os.Remove(b.loc)
+	}
+}
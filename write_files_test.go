@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFilesRollsBackOnFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	a_loc := filepath.Join(dir, "a.go")
+	original := []byte("package p\n\nvar OriginalA = 1\n")
+
+	if err := os.WriteFile(a_loc, original, 0644); err != nil {
+		t.Fatalf("failed to seed a.go: %s", err.Error())
+	}
+
+	blocker := filepath.Join(dir, "blocker")
+
+	if err := os.WriteFile(blocker, []byte("not a directory"), 0644); err != nil {
+		t.Fatalf("failed to seed blocker: %s", err.Error())
+	}
+
+	r := &Result{
+		Files: []*Generated{
+			{DestLoc: a_loc, Data: []byte("package p\n\nvar OriginalA = 2\n")},
+			{DestLoc: filepath.Join(blocker, "b.go"), Data: []byte("package p\n\nvar B = 1\n")},
+		},
+	}
+
+	if err := r.WriteFiles(); err == nil {
+		t.Fatalf("expected WriteFiles to fail when a destination directory cannot be created")
+	}
+
+	got, err := os.ReadFile(a_loc)
+	if err != nil {
+		t.Fatalf("failed to read a.go after rollback: %s", err.Error())
+	}
+
+	if string(got) != string(original) {
+		t.Errorf("expected a.go to be rolled back to %q, got %q", original, got)
+	}
+}